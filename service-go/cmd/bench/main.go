@@ -0,0 +1,186 @@
+// Command bench drives concurrent HTTP load against the thesis-benchmark
+// server and reports latency percentiles, throughput, and a status-code
+// histogram, so that HelloHandler and future endpoints can be compared
+// on equal footing.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/skskillls/thesis-benchmark/service-go/histogram"
+)
+
+// Result is the outcome of a bench run, suitable for human-readable or
+// JSON output.
+type Result struct {
+	URL         string            `json:"url"`
+	Workers     int               `json:"workers"`
+	Requests    uint64            `json:"requests"`
+	Duration    time.Duration     `json:"duration_ns"`
+	RPS         float64           `json:"requests_per_second"`
+	Latency     histogram.Summary `json:"latency"`
+	StatusCodes map[int]uint64    `json:"status_codes"`
+}
+
+func main() {
+	var (
+		url      = flag.String("url", "http://localhost:8080/", "URL to load test")
+		workers  = flag.Int("workers", 10, "number of concurrent workers")
+		requests = flag.Uint64("requests", 1000, "total number of requests to send (0 means use -duration instead)")
+		duration = flag.Duration("duration", 0, "how long to run the benchmark for, instead of a fixed request count")
+		warmup   = flag.Duration("warmup", 0, "warmup period before latencies are recorded")
+		jsonOut  = flag.Bool("json", false, "emit the result as JSON instead of human-readable text")
+		timeout  = flag.Duration("timeout", 10*time.Second, "per-request client timeout")
+	)
+	flag.Parse()
+
+	if *duration == 0 && *requests == 0 {
+		fmt.Fprintln(os.Stderr, "bench: one of -requests or -duration must be non-zero")
+		os.Exit(2)
+	}
+
+	client := &http.Client{Timeout: *timeout}
+
+	if *warmup > 0 {
+		runWarmup(client, *url, *workers, *warmup)
+	}
+
+	result := run(client, *url, *workers, *requests, *duration)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintln(os.Stderr, "bench: encode result:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printResult(os.Stdout, result)
+}
+
+// runWarmup sends load for d without recording any results, so that
+// connection pools and server caches are primed before measurement starts.
+func runWarmup(client *http.Client, url string, workers int, d time.Duration) {
+	deadline := time.Now().Add(d)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				resp, err := client.Get(url)
+				if err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// run drives load against url with the given number of workers, stopping
+// once totalRequests have been sent if totalRequests is non-zero, and
+// otherwise once d has elapsed; d is ignored when totalRequests is
+// non-zero. It returns the aggregated Result.
+func run(client *http.Client, url string, workers int, totalRequests uint64, d time.Duration) Result {
+	var (
+		remaining  = totalRequests
+		useCount   = totalRequests > 0
+		deadline   time.Time
+		statusMu   sync.Mutex
+		statusCnt  = make(map[int]uint64)
+		workerHist = make([]*histogram.Histogram, workers)
+		sent       uint64
+		wg         sync.WaitGroup
+	)
+	if !useCount {
+		deadline = time.Now().Add(d)
+	}
+
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		workerHist[i] = histogram.New()
+		wg.Add(1)
+		go func(h *histogram.Histogram) {
+			defer wg.Done()
+			for {
+				if useCount {
+					if !claimOne(&remaining) {
+						return
+					}
+				} else if time.Now().After(deadline) {
+					return
+				}
+
+				reqStart := time.Now()
+				resp, err := client.Get(url)
+				elapsed := time.Since(reqStart)
+
+				status := 0
+				if err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					status = resp.StatusCode
+				}
+
+				h.Record(elapsed)
+				statusMu.Lock()
+				statusCnt[status]++
+				sent++
+				statusMu.Unlock()
+			}
+		}(workerHist[i])
+	}
+	wg.Wait()
+	total := time.Since(start)
+
+	agg := histogram.New()
+	for _, h := range workerHist {
+		agg.Merge(h)
+	}
+
+	return Result{
+		URL:         url,
+		Workers:     workers,
+		Requests:    sent,
+		Duration:    total,
+		RPS:         float64(sent) / total.Seconds(),
+		Latency:     agg.Summarize(),
+		StatusCodes: statusCnt,
+	}
+}
+
+// claimOne atomically decrements *remaining if it is > 0, reporting
+// whether a unit of work was claimed.
+func claimOne(remaining *uint64) bool {
+	for {
+		cur := atomic.LoadUint64(remaining)
+		if cur == 0 {
+			return false
+		}
+		if atomic.CompareAndSwapUint64(remaining, cur, cur-1) {
+			return true
+		}
+	}
+}
+
+func printResult(w io.Writer, r Result) {
+	fmt.Fprintf(w, "URL:      %s\n", r.URL)
+	fmt.Fprintf(w, "Workers:  %d\n", r.Workers)
+	fmt.Fprintf(w, "Requests: %d in %s (%.1f req/s)\n", r.Requests, r.Duration, r.RPS)
+	fmt.Fprintf(w, "Latency:  %s\n", r.Latency)
+	fmt.Fprintln(w, "Status codes:")
+	for code, count := range r.StatusCodes {
+		fmt.Fprintf(w, "  %d: %d\n", code, count)
+	}
+}
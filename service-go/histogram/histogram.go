@@ -0,0 +1,157 @@
+// Package histogram implements a bounded-memory latency histogram in the
+// style of HdrHistogram: values are tracked in logarithmically-spaced
+// magnitude buckets, each subdivided into a fixed number of linear
+// sub-buckets, so that recording a value and estimating quantiles both cost
+// O(1) and the backing storage never grows with the number of samples
+// recorded.
+package histogram
+
+import (
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// lowestTrackable and highestTrackable bound the values this histogram
+	// can record; values outside the range are clamped to the nearest
+	// bound rather than dropped, so totals stay accurate even if a rare
+	// outlier falls outside the configured range.
+	lowestTrackable  = int64(time.Microsecond)
+	highestTrackable = int64(60 * time.Second)
+
+	// subBucketBits controls the linear resolution within each magnitude:
+	// 2^subBucketBits sub-buckets per doubling of range.
+	subBucketBits  = 8
+	subBucketCount = 1 << subBucketBits
+)
+
+var (
+	minMagnitude = magnitudeOf(lowestTrackable)
+	maxMagnitude = magnitudeOf(highestTrackable)
+	bucketCount  = maxMagnitude - minMagnitude + 1
+)
+
+// magnitudeOf returns floor(log2(v)) for v >= 1.
+func magnitudeOf(v int64) int {
+	return bits.Len64(uint64(v)) - 1
+}
+
+// Histogram is a latency histogram with values recorded in nanoseconds.
+// Record may be called from several goroutines at once; TotalCount,
+// Quantile, Summarize, and reading the source of Merge use plain loads, so
+// they are not safe to run concurrently with Record on the same histogram.
+// The zero value is not usable; call New.
+type Histogram struct {
+	counts []uint64
+}
+
+// New returns an empty Histogram.
+func New() *Histogram {
+	return &Histogram{counts: make([]uint64, bucketCount*subBucketCount)}
+}
+
+// indexFor returns the flat counts index for a nanosecond value, clamping
+// to the trackable range.
+func indexFor(ns int64) int {
+	if ns < lowestTrackable {
+		ns = lowestTrackable
+	}
+	if ns > highestTrackable {
+		ns = highestTrackable
+	}
+	m := magnitudeOf(ns)
+	shift := 0
+	if m > subBucketBits {
+		shift = m - subBucketBits
+	}
+	subIndex := (ns - (int64(1) << uint(m))) >> uint(shift)
+	bucketIndex := m - minMagnitude
+	return bucketIndex*subBucketCount + int(subIndex)
+}
+
+// valueFor returns the representative value (the midpoint of the
+// sub-bucket's range) for a flat counts index, in nanoseconds.
+func valueFor(index int) int64 {
+	bucketIndex := index / subBucketCount
+	subIndex := int64(index % subBucketCount)
+	m := bucketIndex + minMagnitude
+	shift := 0
+	if m > subBucketBits {
+		shift = m - subBucketBits
+	}
+	width := int64(1) << uint(shift)
+	return (int64(1) << uint(m)) + subIndex*width + width/2
+}
+
+// Record adds d to the histogram.
+func (h *Histogram) Record(d time.Duration) {
+	atomic.AddUint64(&h.counts[indexFor(int64(d))], 1)
+}
+
+// Merge adds other's counts into h. Both histograms must have been created
+// with New, since the bucket layout is fixed.
+func (h *Histogram) Merge(other *Histogram) {
+	for i, c := range other.counts {
+		if c != 0 {
+			atomic.AddUint64(&h.counts[i], c)
+		}
+	}
+}
+
+// TotalCount returns the number of values recorded.
+func (h *Histogram) TotalCount() uint64 {
+	var total uint64
+	for _, c := range h.counts {
+		total += c
+	}
+	return total
+}
+
+// Quantile returns the estimated value at the given quantile (e.g. 0.99 for
+// p99) as a time.Duration. It returns 0 if no values have been recorded.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	total := h.TotalCount()
+	if total == 0 {
+		return 0
+	}
+	target := uint64(q * float64(total))
+	if target >= total {
+		target = total - 1
+	}
+
+	var cumulative uint64
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative > target {
+			return time.Duration(valueFor(i))
+		}
+	}
+	return time.Duration(valueFor(len(h.counts) - 1))
+}
+
+// Summary holds the commonly reported percentiles for a Histogram.
+type Summary struct {
+	Count uint64        `json:"count"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	P999  time.Duration `json:"p999_ns"`
+}
+
+// Summarize computes the standard percentile report for h.
+func (h *Histogram) Summarize() Summary {
+	return Summary{
+		Count: h.TotalCount(),
+		P50:   h.Quantile(0.50),
+		P90:   h.Quantile(0.90),
+		P99:   h.Quantile(0.99),
+		P999:  h.Quantile(0.999),
+	}
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("count=%d p50=%s p90=%s p99=%s p999=%s",
+		s.Count, s.P50, s.P90, s.P99, s.P999)
+}
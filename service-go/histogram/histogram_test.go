@@ -0,0 +1,99 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+)
+
+// withinRelTolerance reports whether got is within tol (a fraction, e.g.
+// 0.05 for 5%) of want. The log-linear bucketing trades some precision for
+// bounded memory, so quantile checks use a tolerance rather than exact
+// equality.
+func withinRelTolerance(got, want time.Duration, tol float64) bool {
+	diff := got - want
+	if diff < 0 {
+		diff = -diff
+	}
+	return float64(diff) <= tol*float64(want)
+}
+
+func TestQuantileUniformDistribution(t *testing.T) {
+	h := New()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	cases := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.50, 500 * time.Millisecond},
+		{0.90, 900 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+	}
+	for _, c := range cases {
+		got := h.Quantile(c.q)
+		if !withinRelTolerance(got, c.want, 0.05) {
+			t.Errorf("Quantile(%v) = %v, want ~%v", c.q, got, c.want)
+		}
+	}
+}
+
+func TestQuantileEmptyHistogramIsZero(t *testing.T) {
+	h := New()
+	if got := h.Quantile(0.50); got != 0 {
+		t.Errorf("Quantile on empty histogram = %v, want 0", got)
+	}
+	if got := h.TotalCount(); got != 0 {
+		t.Errorf("TotalCount on empty histogram = %d, want 0", got)
+	}
+}
+
+func TestRecordClampsOutOfRangeValues(t *testing.T) {
+	h := New()
+	h.Record(0)         // below lowestTrackable
+	h.Record(time.Hour) // above highestTrackable
+	if got := h.TotalCount(); got != 2 {
+		t.Fatalf("TotalCount() = %d, want 2", got)
+	}
+	if got := h.Quantile(0.50); got <= 0 {
+		t.Errorf("Quantile(0.5) = %v, want a positive clamped value", got)
+	}
+}
+
+func TestMergeAggregatesCounts(t *testing.T) {
+	a := New()
+	for i := 1; i <= 500; i++ {
+		a.Record(time.Duration(i) * time.Millisecond)
+	}
+	b := New()
+	for i := 501; i <= 1000; i++ {
+		b.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	a.Merge(b)
+
+	if got, want := a.TotalCount(), uint64(1000); got != want {
+		t.Fatalf("TotalCount() after merge = %d, want %d", got, want)
+	}
+	if got, want := a.Quantile(0.50), 500*time.Millisecond; !withinRelTolerance(got, want, 0.05) {
+		t.Errorf("Quantile(0.5) after merge = %v, want ~%v", got, want)
+	}
+	if got, want := a.Quantile(0.99), 990*time.Millisecond; !withinRelTolerance(got, want, 0.05) {
+		t.Errorf("Quantile(0.99) after merge = %v, want ~%v", got, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	h := New()
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	s := h.Summarize()
+	if s.Count != 100 {
+		t.Errorf("Summarize().Count = %d, want 100", s.Count)
+	}
+	if s.P50 == 0 || s.P99 == 0 {
+		t.Errorf("Summarize() = %+v, want non-zero percentiles", s)
+	}
+}
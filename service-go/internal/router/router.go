@@ -0,0 +1,236 @@
+// Package router implements a small method-aware HTTP router. Routes are
+// stored in a trie keyed by path segment, so lookup cost is O(number of
+// path segments) regardless of how many routes are registered - this is
+// what makes it meaningful to compare against stdlib ServeMux and
+// third-party routers for the thesis's benchmarks.
+package router
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// node is one segment of the route trie. A node may have any number of
+// static children (keyed by literal segment text) plus at most one param
+// child (for a "{name}" segment), since two distinct parameter names at
+// the same position would be ambiguous.
+type node struct {
+	children   map[string]*node
+	paramChild *node
+	paramName  string
+
+	pattern  string
+	handlers map[string]http.HandlerFunc
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// Router dispatches requests to handlers registered with GET, POST, etc,
+// supporting path parameters like "/users/{id}" and distinguishing 404
+// (no route matches the path) from 405 (a route matches the path but not
+// the method).
+type Router struct {
+	root *node
+}
+
+// New returns an empty Router.
+func New() *Router {
+	return &Router{root: newNode()}
+}
+
+// Handle registers handler for method and pattern. pattern segments
+// wrapped in braces, e.g. "{id}", are captured as path parameters.
+func (rt *Router) Handle(method, pattern string, handler http.HandlerFunc) {
+	cur := rt.root
+	for _, seg := range splitPath(pattern) {
+		if name, ok := paramName(seg); ok {
+			if cur.paramChild == nil {
+				cur.paramChild = newNode()
+				cur.paramChild.paramName = name
+			}
+			cur = cur.paramChild
+			continue
+		}
+		child, ok := cur.children[seg]
+		if !ok {
+			child = newNode()
+			cur.children[seg] = child
+		}
+		cur = child
+	}
+	if cur.handlers == nil {
+		cur.handlers = make(map[string]http.HandlerFunc)
+	}
+	cur.handlers[method] = handler
+	cur.pattern = pattern
+}
+
+// GET, POST, PUT, PATCH, and DELETE are convenience wrappers around Handle
+// for their respective HTTP methods.
+func (rt *Router) GET(pattern string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodGet, pattern, handler)
+}
+func (rt *Router) POST(pattern string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodPost, pattern, handler)
+}
+func (rt *Router) PUT(pattern string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodPut, pattern, handler)
+}
+func (rt *Router) PATCH(pattern string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodPatch, pattern, handler)
+}
+func (rt *Router) DELETE(pattern string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodDelete, pattern, handler)
+}
+
+// ServeHTTP implements http.Handler, dispatching to the registered handler
+// for the request's method and path, a 405 if the path matches a route
+// but not for this method, or a 404 if match finds no route for the path.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n, params := rt.match(r.URL.Path)
+	if n == nil || len(n.handlers) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	handler, ok := n.handlers[r.Method]
+	if !ok {
+		w.Header().Set("Allow", strings.Join(sortedMethods(n.handlers), ", "))
+		http.Error(w, "405 method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), paramsContextKey{}, params)
+	ctx = context.WithValue(ctx, patternContextKey{}, n.pattern)
+	handler(w, r.WithContext(ctx))
+}
+
+// match walks the trie for path, returning the matching node (if any) and
+// any path parameters captured along the way. A static segment always wins
+// over a parameter at the same position and the walk never backtracks, so
+// a path that only a parameter route could match, once a static child has
+// been taken at an earlier segment, matches nothing.
+func (rt *Router) match(path string) (*node, map[string]string) {
+	cur := rt.root
+	params := map[string]string{}
+	for _, seg := range splitPath(path) {
+		if child, ok := cur.children[seg]; ok {
+			cur = child
+			continue
+		}
+		if cur.paramChild != nil {
+			params[cur.paramChild.paramName] = seg
+			cur = cur.paramChild
+			continue
+		}
+		return nil, nil
+	}
+	return cur, params
+}
+
+// MatchPattern returns the registered route pattern matching path, or ""
+// if none does. Unlike Pattern, it works from a path alone, so middleware
+// wrapping the router can resolve a pattern without unwinding back through
+// the request context after ServeHTTP returns (e.g. to key metrics on the
+// route actually served instead of the raw, parameterized path).
+func (rt *Router) MatchPattern(path string) string {
+	n, _ := rt.match(path)
+	if n == nil {
+		return ""
+	}
+	return n.pattern
+}
+
+// RouteInfo describes one registered method+pattern pair.
+type RouteInfo struct {
+	Method  string
+	Pattern string
+}
+
+// Routes returns every registered route, sorted by pattern then method,
+// so that callers like a /metrics handler can pre-register a zero-valued
+// counter for each one.
+func (rt *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n == nil {
+			return
+		}
+		for _, method := range sortedMethods(n.handlers) {
+			routes = append(routes, RouteInfo{Method: method, Pattern: n.pattern})
+		}
+		for _, seg := range sortedKeys(n.children) {
+			walk(n.children[seg])
+		}
+		walk(n.paramChild)
+	}
+	walk(rt.root)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+type paramsContextKey struct{}
+type patternContextKey struct{}
+
+// Params returns the path parameters captured for r by the route that
+// matched it, or nil if there were none.
+func Params(r *http.Request) map[string]string {
+	v, _ := r.Context().Value(paramsContextKey{}).(map[string]string)
+	return v
+}
+
+// Pattern returns the registered route pattern that matched r (e.g.
+// "/users/{id}"), or "" if no route matched. It is intended for
+// instrumentation, such as keying metrics without raw-path cardinality.
+func Pattern(r *http.Request) string {
+	v, _ := r.Context().Value(patternContextKey{}).(string)
+	return v
+}
+
+// splitPath splits a URL path into its non-empty segments.
+func splitPath(path string) []string {
+	parts := strings.Split(path, "/")
+	segments := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			segments = append(segments, p)
+		}
+	}
+	return segments
+}
+
+// paramName reports whether seg is a "{name}" path parameter segment.
+func paramName(seg string) (string, bool) {
+	if len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}' {
+		return seg[1 : len(seg)-1], true
+	}
+	return "", false
+}
+
+func sortedMethods(handlers map[string]http.HandlerFunc) []string {
+	methods := make([]string, 0, len(handlers))
+	for m := range handlers {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func sortedKeys(children map[string]*node) []string {
+	keys := make([]string, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParamCapture(t *testing.T) {
+	rt := New()
+	var gotParams map[string]string
+	rt.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		gotParams = Params(r)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if gotParams["id"] != "42" {
+		t.Errorf("Params()[\"id\"] = %q, want \"42\"", gotParams["id"])
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	rt := New()
+	rt.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/widgets/1", nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	rt := New()
+	rt.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	rt.DELETE("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/42", nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := w.Header().Get("Allow"); allow != "DELETE, GET" {
+		t.Errorf("Allow header = %q, want %q", allow, "DELETE, GET")
+	}
+}
+
+func TestRoutesIntrospection(t *testing.T) {
+	rt := New()
+	rt.GET("/", func(w http.ResponseWriter, r *http.Request) {})
+	rt.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	rt.POST("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	routes := rt.Routes()
+	want := map[RouteInfo]bool{
+		{Method: http.MethodGet, Pattern: "/"}:            true,
+		{Method: http.MethodGet, Pattern: "/users/{id}"}:  true,
+		{Method: http.MethodPost, Pattern: "/users/{id}"}: true,
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("Routes() returned %d entries, want %d", len(routes), len(want))
+	}
+	for _, r := range routes {
+		if !want[r] {
+			t.Errorf("unexpected route %+v", r)
+		}
+	}
+}
+
+func TestMatchPatternConsistentWithDispatch(t *testing.T) {
+	rt := New()
+	var dispatched string
+	rt.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		dispatched = Pattern(r)
+	})
+
+	const path = "/users/42"
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	if got, want := rt.MatchPattern(path), "/users/{id}"; got != want {
+		t.Errorf("MatchPattern(%q) = %q, want %q", path, got, want)
+	}
+	if got := rt.MatchPattern(path); got != dispatched {
+		t.Errorf("MatchPattern(%q) = %q, but dispatch used pattern %q", path, got, dispatched)
+	}
+	if got := rt.MatchPattern("/no/such/route"); got != "" {
+		t.Errorf("MatchPattern for unregistered path = %q, want \"\"", got)
+	}
+}
@@ -1,9 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"github.com/skskillls/thesis-benchmark/service-go/internal/router"
+	"github.com/skskillls/thesis-benchmark/service-go/middleware"
 )
 
 // HelloHandler handles the root endpoint
@@ -11,8 +21,197 @@ func HelloHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Hello from Go! Time: %s", time.Now())
 }
 
+// Config holds the tunables for RunServer. Each field can be set from the
+// matching command-line flag or environment variable of the same name
+// (e.g. -port / PORT).
+type Config struct {
+	Port              int
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	ShutdownTimeout   time.Duration
+
+	// LogSink selects the request log sink: "stdout" (human-readable
+	// text), "json" (JSON lines to stdout), or "file" (async, rotating,
+	// written to LogFile).
+	LogSink     string
+	LogFile     string
+	LogMaxBytes int64
+}
+
+// DefaultConfig returns the Config used when no flags or environment
+// variables override it.
+func DefaultConfig() Config {
+	return Config{
+		Port:              8080,
+		ReadTimeout:       5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		ShutdownTimeout:   15 * time.Second,
+		LogSink:           "stdout",
+	}
+}
+
+// configFromFlags parses Config fields from command-line flags, falling
+// back to environment variables and then the supplied defaults.
+func configFromFlags(defaults Config) Config {
+	cfg := defaults
+	flag.IntVar(&cfg.Port, "port", envInt("PORT", defaults.Port), "port to listen on")
+	flag.DurationVar(&cfg.ReadTimeout, "read-timeout", envDuration("READ_TIMEOUT", defaults.ReadTimeout), "maximum duration for reading the entire request")
+	flag.DurationVar(&cfg.WriteTimeout, "write-timeout", envDuration("WRITE_TIMEOUT", defaults.WriteTimeout), "maximum duration before timing out writes of the response")
+	flag.DurationVar(&cfg.IdleTimeout, "idle-timeout", envDuration("IDLE_TIMEOUT", defaults.IdleTimeout), "maximum amount of time to wait for the next request when keep-alives are enabled")
+	flag.DurationVar(&cfg.ReadHeaderTimeout, "read-header-timeout", envDuration("READ_HEADER_TIMEOUT", defaults.ReadHeaderTimeout), "maximum duration for reading request headers")
+	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", envDuration("SHUTDOWN_TIMEOUT", defaults.ShutdownTimeout), "maximum duration to wait for in-flight requests to drain on shutdown")
+	flag.StringVar(&cfg.LogSink, "log-sink", envString("LOG_SINK", defaults.LogSink), "request log sink: stdout, json, or file")
+	flag.StringVar(&cfg.LogFile, "log-file", envString("LOG_FILE", defaults.LogFile), "file path for the file log sink (required when -log-sink=file)")
+	flag.Int64Var(&cfg.LogMaxBytes, "log-max-bytes", envInt64("LOG_MAX_BYTES", defaults.LogMaxBytes), "rotate the file log sink after this many bytes (0 disables rotation)")
+	flag.Parse()
+	return cfg
+}
+
+func envInt(key string, fallback int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var n int
+	if _, err := fmt.Sscanf(v, "%d", &n); err != nil {
+		return fallback
+	}
+	return n
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+func envString(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func envInt64(key string, fallback int64) int64 {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var n int64
+	if _, err := fmt.Sscanf(v, "%d", &n); err != nil {
+		return fallback
+	}
+	return n
+}
+
+// newRequestLogSink builds the middleware.Sink selected by cfg.LogSink. The
+// second return value is non-nil when the sink owns a resource the caller
+// must close on shutdown (currently only the file sink).
+func newRequestLogSink(cfg Config) (middleware.Sink, io.Closer, error) {
+	switch cfg.LogSink {
+	case "", "stdout":
+		return middleware.NewStdoutSink(), nil, nil
+	case "json":
+		return middleware.NewJSONSink(os.Stdout), nil, nil
+	case "file":
+		if cfg.LogFile == "" {
+			return nil, nil, fmt.Errorf("main: -log-file must be set when -log-sink=file")
+		}
+		fs, err := middleware.NewFileSink(cfg.LogFile, cfg.LogMaxBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fs, fs, nil
+	default:
+		return nil, nil, fmt.Errorf("main: unknown -log-sink %q (want stdout, json, or file)", cfg.LogSink)
+	}
+}
+
+// RunServer builds an *http.Server from cfg, serves HelloHandler behind the
+// logging middleware, and blocks until ctx is canceled or the process
+// receives SIGINT/SIGTERM, at which point it drains in-flight requests for
+// up to cfg.ShutdownTimeout before returning. ctx is exported as a
+// parameter, rather than only reacting to OS signals, so benchmark
+// harnesses can start and stop the server programmatically inside tests.
+func RunServer(ctx context.Context, cfg Config) error {
+	sink, sinkCloser, err := newRequestLogSink(cfg)
+	if err != nil {
+		return err
+	}
+	if sinkCloser != nil {
+		defer sinkCloser.Close()
+	}
+
+	rt := router.New()
+	rt.GET("/", HelloHandler)
+
+	metrics := middleware.NewMetrics()
+	rt.GET("/debug/vars", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		metrics.WriteDebugVars(w)
+	})
+	rt.GET("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		metrics.WritePrometheus(w)
+	})
+
+	for _, route := range rt.Routes() {
+		metrics.RegisterRoute(route.Method, route.Pattern)
+	}
+
+	handler := middleware.Chain(rt,
+		middleware.RequestLogger(sink),
+		metrics.Middleware(func(r *http.Request) string { return rt.MatchPattern(r.URL.Path) }),
+	)
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %d...", cfg.Port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		stop()
+		log.Println("shutting down, draining in-flight requests...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
 func main() {
-	http.HandleFunc("/", HelloHandler)
-	fmt.Println("Server starting on port 8080...")
-	http.ListenAndServe(":8080", nil)
-}
\ No newline at end of file
+	cfg := configFromFlags(DefaultConfig())
+	if err := RunServer(context.Background(), cfg); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// waitForServer retries a GET against addr until it succeeds or deadline
+// elapses, so the test doesn't race RunServer's startup goroutine.
+func waitForServer(t *testing.T, addr string, deadline time.Duration) {
+	t.Helper()
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	for time.Since(start) < deadline {
+		resp, err := client.Get("http://" + addr + "/")
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server at %s did not become ready within %s", addr, deadline)
+}
+
+// TestRunServerStartStopViaContext drives RunServer the way a benchmark
+// harness would: start it with a cancellable context, issue a request, then
+// cancel the context and confirm it shuts down cleanly within
+// ShutdownTimeout and actually releases the listening socket.
+func TestRunServerStartStopViaContext(t *testing.T) {
+	const port = 18791
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+
+	cfg := DefaultConfig()
+	cfg.Port = port
+	cfg.ShutdownTimeout = 2 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- RunServer(ctx, cfg)
+	}()
+
+	waitForServer(t, addr, 2*time.Second)
+
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET / status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("RunServer returned error: %v", err)
+		}
+	case <-time.After(cfg.ShutdownTimeout + time.Second):
+		t.Fatal("RunServer did not return within ShutdownTimeout")
+	}
+
+	if _, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+		t.Fatalf("expected %s to be closed after shutdown, but it accepted a connection", addr)
+	}
+}
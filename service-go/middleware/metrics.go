@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the upper bounds (in seconds) of the Prometheus
+// histogram buckets used for http_request_duration_seconds. They follow
+// the client_golang defaults, which cover typical web handler latencies.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RoutePattern resolves the matched route pattern for a request (e.g.
+// "/users/{id}"), as opposed to the raw, potentially high-cardinality
+// request path. Callers without a router can fall back to r.URL.Path.
+type RoutePattern func(r *http.Request) string
+
+// countKey identifies one series of the http_requests_total counter.
+type countKey struct {
+	method  string
+	pattern string
+	status  int
+}
+
+// durationSeries accumulates one method+pattern's worth of samples for the
+// http_request_duration_seconds histogram.
+type durationSeries struct {
+	mu      sync.Mutex
+	buckets []uint64 // counts per durationBuckets entry, not cumulative
+	sum     float64
+	count   uint64
+}
+
+func newDurationSeries() *durationSeries {
+	return &durationSeries{buckets: make([]uint64, len(durationBuckets))}
+}
+
+func (d *durationSeries) observe(seconds float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, le := range durationBuckets {
+		if seconds <= le {
+			d.buckets[i]++
+			break
+		}
+	}
+	d.sum += seconds
+	d.count++
+}
+
+// Metrics records counters, gauges, and histograms for the server's HTTP
+// handlers and exposes them via WriteDebugVars and WritePrometheus.
+type Metrics struct {
+	startTime time.Time
+
+	inFlight int64 // atomic
+
+	mu        sync.Mutex
+	counts    map[countKey]uint64
+	durations map[string]*durationSeries // keyed by "method pattern"
+}
+
+// NewMetrics returns an empty Metrics recorder.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		startTime: time.Now(),
+		counts:    make(map[countKey]uint64),
+		durations: make(map[string]*durationSeries),
+	}
+}
+
+// Middleware returns middleware that records request counts, in-flight
+// gauge, and duration for every request, keyed on the pattern returned by
+// routePattern rather than the raw path, to avoid cardinality blowup from
+// path parameters.
+func (m *Metrics) Middleware(routePattern RoutePattern) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(&m.inFlight, 1)
+			defer atomic.AddInt64(&m.inFlight, -1)
+
+			start := time.Now()
+			rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			elapsed := time.Since(start)
+
+			pattern := routePattern(r)
+			m.recordCount(r.Method, pattern, rw.status)
+			m.durationFor(r.Method, pattern).observe(elapsed.Seconds())
+		})
+	}
+}
+
+func (m *Metrics) recordCount(method, pattern string, status int) {
+	key := countKey{method: method, pattern: pattern, status: status}
+	m.mu.Lock()
+	m.counts[key]++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) durationFor(method, pattern string) *durationSeries {
+	key := method + " " + pattern
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.durations[key]
+	if !ok {
+		d = newDurationSeries()
+		m.durations[key] = d
+	}
+	return d
+}
+
+// RegisterRoute pre-registers a zero-valued series for method+pattern, so
+// that routes which have never been hit still appear in /metrics.
+func (m *Metrics) RegisterRoute(method, pattern string) {
+	m.durationFor(method, pattern)
+}
+
+// debugVars is the JSON shape served at /debug/vars.
+type debugVars struct {
+	CPUs      int     `json:"cpus"`
+	GoVersion string  `json:"go_version"`
+	Hostname  string  `json:"hostname"`
+	UID       int     `json:"uid"`
+	GID       int     `json:"gid"`
+	UptimeSec float64 `json:"uptime_seconds"`
+}
+
+// WriteDebugVars writes a JSON snapshot of runtime info to w.
+func (m *Metrics) WriteDebugVars(w io.Writer) error {
+	hostname, _ := os.Hostname()
+	v := debugVars{
+		CPUs:      runtime.NumCPU(),
+		GoVersion: runtime.Version(),
+		Hostname:  hostname,
+		UID:       os.Getuid(),
+		GID:       os.Getgid(),
+		UptimeSec: time.Since(m.startTime).Seconds(),
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WritePrometheus streams the current metrics to w in Prometheus text
+// exposition format, without building up an intermediate string.
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	fmt.Fprintln(w, "# HELP http_requests_total Total number of HTTP requests.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	m.mu.Lock()
+	for k, v := range m.counts {
+		fmt.Fprintf(w, "http_requests_total{method=%q,path=%q,status=\"%d\"} %d\n", k.method, k.pattern, k.status, v)
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP http_requests_in_flight Number of HTTP requests currently being served.")
+	fmt.Fprintln(w, "# TYPE http_requests_in_flight gauge")
+	fmt.Fprintf(w, "http_requests_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Latency of HTTP requests.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds histogram")
+	m.mu.Lock()
+	for key, d := range m.durations {
+		method, pattern := splitKey(key)
+		d.mu.Lock()
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += d.buckets[i]
+			fmt.Fprintf(w, "http_request_duration_seconds_bucket{method=%q,path=%q,le=%q} %d\n", method, pattern, formatLE(le), cumulative)
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_bucket{method=%q,path=%q,le=\"+Inf\"} %d\n", method, pattern, d.count)
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{method=%q,path=%q} %g\n", method, pattern, d.sum)
+		fmt.Fprintf(w, "http_request_duration_seconds_count{method=%q,path=%q} %d\n", method, pattern, d.count)
+		d.mu.Unlock()
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP go_goroutines Number of goroutines currently running.")
+	fmt.Fprintln(w, "# TYPE go_goroutines gauge")
+	fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	fmt.Fprintln(w, "# HELP go_memstats_alloc_bytes Bytes of allocated heap objects.")
+	fmt.Fprintln(w, "# TYPE go_memstats_alloc_bytes gauge")
+	fmt.Fprintf(w, "go_memstats_alloc_bytes %d\n", ms.Alloc)
+	fmt.Fprintln(w, "# HELP go_memstats_sys_bytes Total bytes of memory obtained from the OS.")
+	fmt.Fprintln(w, "# TYPE go_memstats_sys_bytes gauge")
+	fmt.Fprintf(w, "go_memstats_sys_bytes %d\n", ms.Sys)
+	fmt.Fprintln(w, "# HELP go_memstats_heap_alloc_bytes Bytes of allocated heap objects.")
+	fmt.Fprintln(w, "# TYPE go_memstats_heap_alloc_bytes gauge")
+	fmt.Fprintf(w, "go_memstats_heap_alloc_bytes %d\n", ms.HeapAlloc)
+}
+
+func splitKey(key string) (method, pattern string) {
+	for i := 0; i < len(key); i++ {
+		if key[i] == ' ' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return key, ""
+}
+
+func formatLE(le float64) string {
+	return fmt.Sprintf("%g", le)
+}
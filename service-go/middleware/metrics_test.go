@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func staticPattern(pattern string) RoutePattern {
+	return func(r *http.Request) string { return pattern }
+}
+
+func TestMetricsMiddlewareRecordsPrometheusOutput(t *testing.T) {
+	m := NewMetrics()
+	m.RegisterRoute(http.MethodGet, "/never-hit")
+
+	handler := m.Middleware(staticPattern("/hello"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("hi"))
+	}))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+	}
+
+	var buf bytes.Buffer
+	m.WritePrometheus(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, `http_requests_total{method="GET",path="/hello",status="200"} 3`) {
+		t.Errorf("missing expected http_requests_total line, got:\n%s", out)
+	}
+	if !strings.Contains(out, `http_request_duration_seconds_bucket{method="GET",path="/hello",le=`) {
+		t.Errorf("missing duration buckets for hit route, got:\n%s", out)
+	}
+	if !strings.Contains(out, `http_request_duration_seconds_count{method="GET",path="/hello"} 3`) {
+		t.Errorf("missing duration count for hit route, got:\n%s", out)
+	}
+	if !strings.Contains(out, `http_request_duration_seconds_count{method="GET",path="/never-hit"} 0`) {
+		t.Errorf("pre-registered zero-count route missing, got:\n%s", out)
+	}
+}
+
+func TestWriteDebugVarsRoundTrips(t *testing.T) {
+	m := NewMetrics()
+
+	var buf bytes.Buffer
+	if err := m.WriteDebugVars(&buf); err != nil {
+		t.Fatalf("WriteDebugVars: %v", err)
+	}
+
+	var got debugVars
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	if got.CPUs <= 0 {
+		t.Errorf("CPUs = %d, want > 0", got.CPUs)
+	}
+	if got.GoVersion == "" {
+		t.Errorf("GoVersion is empty")
+	}
+	if got.Hostname == "" {
+		t.Errorf("Hostname is empty")
+	}
+	if got.UptimeSec < 0 {
+		t.Errorf("UptimeSec = %v, want >= 0", got.UptimeSec)
+	}
+}
@@ -0,0 +1,95 @@
+// Package middleware provides composable http.Handler wrappers for the
+// thesis-benchmark server, starting with request logging so that every
+// handler's behavior can be measured consistently.
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps an http.Handler to produce a new http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies the given middleware to h in order, so that the first
+// middleware in the list is the outermost wrapper (the first to see the
+// request and the last to see the response).
+func Chain(h http.Handler, mw ...Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// requestIDHeader is the header used to propagate the generated request ID
+// to clients and downstream handlers.
+const requestIDHeader = "X-Request-ID"
+
+// RequestLogger returns middleware that records one Entry per request to
+// sink, capturing remote addr, method, path, status, bytes written,
+// duration, and a request ID: the incoming X-Request-ID header if the
+// client sent one, otherwise a generated one.
+func RequestLogger(sink Sink) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+			w.Header().Set(requestIDHeader, id)
+
+			start := time.Now()
+			rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			sink.Write(Entry{
+				RequestID:  id,
+				RemoteAddr: r.RemoteAddr,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     rw.status,
+				Bytes:      rw.bytes,
+				Duration:   time.Since(start),
+				Time:       start,
+			})
+		})
+	}
+}
+
+// newRequestID generates a short random hex identifier for a request.
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// statusWriter wraps an http.ResponseWriter to record the status code and
+// byte count written, since the stdlib does not expose either after the
+// fact.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.status = code
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += n
+	return n, err
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is a single request log record produced by RequestLogger.
+type Entry struct {
+	RequestID  string        `json:"request_id"`
+	RemoteAddr string        `json:"remote_addr"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	Status     int           `json:"status"`
+	Bytes      int           `json:"bytes"`
+	Duration   time.Duration `json:"duration_ns"`
+	Time       time.Time     `json:"time"`
+}
+
+// Sink receives log entries as requests complete. Implementations must be
+// safe for concurrent use.
+type Sink interface {
+	Write(e Entry)
+}
+
+// textLine formats an Entry the way StdoutSink and FileSink (text mode)
+// render it.
+func textLine(e Entry) string {
+	return fmt.Sprintf("%s %s %s %s %d %dB %s\n",
+		e.Time.Format(time.RFC3339), e.RequestID, e.Method, e.Path, e.Status, e.Bytes, e.Duration)
+}
+
+// StdoutSink writes one human-readable text line per entry to os.Stdout.
+type StdoutSink struct {
+	mu sync.Mutex
+}
+
+// NewStdoutSink returns a Sink that writes text lines to os.Stdout.
+func NewStdoutSink() *StdoutSink {
+	return &StdoutSink{}
+}
+
+func (s *StdoutSink) Write(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(os.Stdout, textLine(e))
+}
+
+// JSONSink writes one JSON object per line (JSON Lines) to the given
+// io.Writer.
+type JSONSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewJSONSink returns a Sink that writes newline-delimited JSON to w.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w, enc: json.NewEncoder(w)}
+}
+
+func (s *JSONSink) Write(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Errors are not actionable for a logging sink; dropping the entry is
+	// preferable to taking down the request path.
+	_ = s.enc.Encode(e)
+}
+
+// FileSink asynchronously writes text entries to disk, buffering them on a
+// channel so that slow disk I/O does not block the request path until
+// DefaultBufferSize entries are queued, and rotating the underlying file
+// once the bytes written to it reach maxBytes.
+type FileSink struct {
+	path     string
+	maxBytes int64
+
+	entries   chan Entry
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	file    *os.File
+	writer  *bufio.Writer
+	written int64
+}
+
+// DefaultBufferSize is the number of entries FileSink buffers before Write
+// starts blocking the caller.
+const DefaultBufferSize = 1024
+
+// NewFileSink opens path for appending and starts the background writer
+// goroutine. maxBytes is the size at which the file is rotated to
+// "<path>.1"; a value <= 0 disables rotation.
+func NewFileSink(path string, maxBytes int64) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("middleware: open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("middleware: stat log file: %w", err)
+	}
+
+	s := &FileSink{
+		path:     path,
+		maxBytes: maxBytes,
+		entries:  make(chan Entry, DefaultBufferSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		file:     f,
+		writer:   bufio.NewWriter(f),
+		written:  info.Size(),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write is safe to call concurrently with Close: s.entries is never
+// closed, so there is no send on a closed channel. Once Close has been
+// called, Write no longer blocks: if the buffer is full it takes the
+// <-s.stop case and drops the entry; if the buffer has room the entry may
+// still be queued, and is written only if the background writer has not
+// yet finished draining.
+func (s *FileSink) Write(e Entry) {
+	select {
+	case s.entries <- e:
+	case <-s.stop:
+	}
+}
+
+// Close signals the background writer to stop, waits for it to flush and
+// drain any already-buffered entries, and closes the underlying file.
+func (s *FileSink) Close() error {
+	s.closeOnce.Do(func() { close(s.stop) })
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writer.Flush()
+	return s.file.Close()
+}
+
+// run drains entries until Close signals stop, then flushes whatever was
+// already buffered before exiting. s.entries is never closed, since
+// multiple goroutines send on it concurrently with Write.
+func (s *FileSink) run() {
+	defer close(s.done)
+	for {
+		select {
+		case e := <-s.entries:
+			s.writeEntry(e)
+		case <-s.stop:
+			for {
+				select {
+				case e := <-s.entries:
+					s.writeEntry(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *FileSink) writeEntry(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := textLine(e)
+	n, err := s.writer.WriteString(line)
+	if err != nil {
+		return
+	}
+	s.written += int64(n)
+
+	if s.maxBytes > 0 && s.written >= s.maxBytes {
+		s.rotate()
+	}
+}
+
+// rotate flushes and closes the current file, renames it to "<path>.1"
+// (replacing any previous backup), and opens a fresh file at path. Callers
+// must hold s.mu.
+func (s *FileSink) rotate() {
+	s.writer.Flush()
+	s.file.Close()
+
+	backup := s.path + ".1"
+	os.Remove(backup)
+	os.Rename(s.path, backup)
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		// Nothing more we can do from inside the writer goroutine. The
+		// closed file is kept and s.written still reaches maxBytes, so the
+		// next writeEntry calls rotate again; that entry's line is lost.
+		return
+	}
+	s.file = f
+	s.writer = bufio.NewWriter(f)
+	s.written = 0
+}